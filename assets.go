@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const maxThumbnailDimension = 200
+
+var assetsBackend StorageBackend
+
+// allowedAssetTypes maps a sniffed MIME type (via http.DetectContentType)
+// to the extension assets of that type are stored with. Anything not in
+// this list is rejected, regardless of the filename the client sent.
+var allowedAssetTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"audio/mpeg":      ".mp3",
+	"audio/wav":       ".wav",
+	"video/mp4":       ".mp4",
+}
+
+func isImageAssetType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+// AssetInfo describes a stored asset and the Jekyll-ready URL a client can
+// paste directly into a post.
+type AssetInfo struct {
+	Filename     string    `json:"filename"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	Size         int64     `json:"size"`
+	UploadTime   time.Time `json:"upload_time"`
+	ContentType  string    `json:"content_type"`
+}
+
+// uploadAsset handles POST /assets. It sniffs the real content type from
+// the first 512 bytes rather than trusting the filename, streams
+// non-image uploads straight to the backend so large files never sit
+// fully in memory, and for images additionally buffers the upload to
+// generate a thumbnail.
+func uploadAsset(w http.ResponseWriter, r *http.Request) {
+	if !validateAPIKey(r) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		respondWithError(w, "Error parsing multipart form", http.StatusBadRequest)
+		return
+	}
+
+	part, err := nextFilePart(mr)
+	if err != nil {
+		respondWithError(w, "Error retrieving file", http.StatusBadRequest)
+		return
+	}
+	defer part.Close()
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(part, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		respondWithError(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	sniff = sniff[:n]
+	contentType := http.DetectContentType(sniff)
+
+	ext, ok := allowedAssetTypes[contentType]
+	if !ok {
+		respondWithError(w, fmt.Sprintf("Unsupported asset type: %s", contentType), http.StatusBadRequest)
+		return
+	}
+
+	id, err := randomAssetID()
+	if err != nil {
+		respondWithError(w, "Error generating asset name", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	key := fmt.Sprintf("%04d/%02d/%s%s", now.Year(), now.Month(), id, ext)
+
+	var size int64
+	var thumbKey string
+
+	if isImageAssetType(contentType) {
+		// +1 so a file exactly at the cap doesn't get truncated silently;
+		// the length check below catches anything over the limit.
+		limit := maxFileSize - int64(len(sniff)) + 1
+		rest, err := io.ReadAll(io.LimitReader(part, limit))
+		if err != nil {
+			respondWithError(w, "Error reading file", http.StatusInternalServerError)
+			return
+		}
+		if int64(len(sniff))+int64(len(rest)) > maxFileSize {
+			respondWithError(w, "File too large", http.StatusBadRequest)
+			return
+		}
+		content := append(sniff, rest...)
+		size = int64(len(content))
+
+		if err := assetsBackend.Put(r.Context(), key, bytes.NewReader(content)); err != nil {
+			respondWithError(w, "Error saving asset", http.StatusInternalServerError)
+			return
+		}
+
+		thumbKey, err = generateThumbnail(r.Context(), key, content, ext)
+		if err != nil {
+			log.Printf("Warning: error generating thumbnail for %s: %v", key, err)
+		}
+	} else {
+		// +1 so a stream exactly at the cap isn't truncated silently; the
+		// size check below catches anything over the limit.
+		limited := io.LimitReader(io.MultiReader(bytes.NewReader(sniff), part), maxFileSize+1)
+		counting := &countingReader{r: limited}
+		if err := assetsBackend.Put(r.Context(), key, counting); err != nil {
+			respondWithError(w, "Error saving asset", http.StatusInternalServerError)
+			return
+		}
+		if counting.n > maxFileSize {
+			if delErr := assetsBackend.Delete(r.Context(), key); delErr != nil {
+				log.Printf("Warning: error rolling back oversized asset %s: %v", key, delErr)
+			}
+			respondWithError(w, "File too large", http.StatusBadRequest)
+			return
+		}
+		size = counting.n
+	}
+
+	info := AssetInfo{
+		Filename:    key,
+		URL:         "/assets/" + key,
+		Size:        size,
+		UploadTime:  now,
+		ContentType: contentType,
+	}
+	if thumbKey != "" {
+		info.ThumbnailURL = "/assets/" + thumbKey
+	}
+
+	respondWithSuccess(w, "Asset uploaded successfully", info)
+}
+
+func getAsset(w http.ResponseWriter, r *http.Request) {
+	if !validateAPIKey(r) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+
+	exists, err := assetsBackend.Exists(r.Context(), key)
+	if err != nil || !exists {
+		respondWithError(w, "Asset not found", http.StatusNotFound)
+		return
+	}
+
+	if err := assetsBackend.ServeFile(w, r, key); err != nil {
+		respondWithError(w, "Error serving asset", http.StatusInternalServerError)
+		return
+	}
+}
+
+func deleteAsset(w http.ResponseWriter, r *http.Request) {
+	if !validateAPIKey(r) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+
+	if err := assetsBackend.Delete(r.Context(), key); err != nil {
+		respondWithError(w, "Error deleting asset", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, "Asset deleted successfully", nil)
+}
+
+// nextFilePart scans a multipart request for the first part named "file".
+func nextFilePart(mr *multipart.Reader) (*multipart.Part, error) {
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
+
+func randomAssetID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// countingReader wraps an io.Reader to track how many bytes were streamed
+// through it, since Put only returns an error and streaming callers never
+// materialize the full body to measure it directly.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// generateThumbnail decodes an image asset and stores a downscaled copy
+// next to it (same key with a "_thumb" suffix), returning the thumbnail's
+// key.
+func generateThumbnail(ctx context.Context, key string, content []byte, ext string) (string, error) {
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("decoding image: %w", err)
+	}
+
+	thumb := resizeToFit(img, maxThumbnailDimension)
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		err = png.Encode(&buf, thumb)
+	default:
+		err = jpeg.Encode(&buf, thumb, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encoding thumbnail: %w", err)
+	}
+
+	thumbKey := strings.TrimSuffix(key, ext) + "_thumb" + ext
+	if err := assetsBackend.Put(ctx, thumbKey, &buf); err != nil {
+		return "", fmt.Errorf("saving thumbnail: %w", err)
+	}
+	return thumbKey, nil
+}
+
+// resizeToFit scales img down (nearest-neighbor) so neither dimension
+// exceeds maxDim, preserving aspect ratio. Images already within bounds
+// are returned unchanged.
+func resizeToFit(img image.Image, maxDim int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxDim && srcH <= maxDim {
+		return img
+	}
+
+	scale := float64(maxDim) / float64(srcW)
+	if srcH > srcW {
+		scale = float64(maxDim) / float64(srcH)
+	}
+	dstW := int(float64(srcW) * scale)
+	dstH := int(float64(srcH) * scale)
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		srcY := bounds.Min.Y + y*srcH/dstH
+		for x := 0; x < dstW; x++ {
+			srcX := bounds.Min.X + x*srcW/dstW
+			dst.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countRegularFiles walks dir and counts plain files, ignoring the (possibly
+// empty) year/month directories the asset key layout creates.
+func countRegularFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("filepath.Walk() error: %v", err)
+	}
+	return n
+}
+
+func multipartAssetRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/assets", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func withTestAssetsBackend(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	b, err := newLocalFSBackend(dir)
+	if err != nil {
+		t.Fatalf("newLocalFSBackend() error: %v", err)
+	}
+
+	origBackend := assetsBackend
+	assetsBackend = b
+	t.Cleanup(func() { assetsBackend = origBackend })
+	return dir
+}
+
+func withTestMaxFileSize(t *testing.T, n int64) {
+	t.Helper()
+	orig := maxFileSize
+	maxFileSize = n
+	t.Cleanup(func() { maxFileSize = orig })
+}
+
+// pdfAsset builds a payload http.DetectContentType recognizes as
+// application/pdf, padded to size with filler bytes after the header.
+func pdfAsset(size int) []byte {
+	header := []byte("%PDF-1.4\n")
+	content := make([]byte, size)
+	copy(content, header)
+	for i := len(header); i < size; i++ {
+		content[i] = 'a'
+	}
+	return content
+}
+
+func TestUploadAssetRejectsOversizedNonImageStream(t *testing.T) {
+	dir := withTestAssetsBackend(t)
+	withTestMaxFileSize(t, 1024)
+
+	req := multipartAssetRequest(t, "doc.pdf", pdfAsset(2048))
+	w := httptest.NewRecorder()
+
+	uploadAsset(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	if n := countRegularFiles(t, dir); n != 0 {
+		t.Errorf("oversized asset was not rolled back, found %d files under %s", n, dir)
+	}
+}
+
+func TestUploadAssetAcceptsNonImageWithinLimit(t *testing.T) {
+	dir := withTestAssetsBackend(t)
+	withTestMaxFileSize(t, 1<<20)
+
+	req := multipartAssetRequest(t, "doc.pdf", pdfAsset(512))
+	w := httptest.NewRecorder()
+
+	uploadAsset(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if n := countRegularFiles(t, dir); n == 0 {
+		t.Errorf("expected the asset to be written under %s, found none", dir)
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	c := &countingReader{r: bytes.NewReader([]byte("hello world"))}
+	got, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("ReadAll() = %q, want %q", got, "hello world")
+	}
+	if c.n != int64(len(got)) {
+		t.Errorf("countingReader.n = %d, want %d", c.n, len(got))
+	}
+}
+
+func TestIsImageAssetType(t *testing.T) {
+	if !isImageAssetType("image/png") {
+		t.Errorf("isImageAssetType(image/png) = false, want true")
+	}
+	if isImageAssetType("video/mp4") {
+		t.Errorf("isImageAssetType(video/mp4) = true, want false")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter is the typed shape of a Jekyll post's YAML front matter.
+// Extra holds any keys we don't otherwise model, so round-tripping a post
+// never drops fields a caller (or a previous version of this API) wrote.
+type FrontMatter struct {
+	Layout     string                 `yaml:"layout,omitempty"`
+	Title      string                 `yaml:"title,omitempty"`
+	Date       string                 `yaml:"date,omitempty"`
+	Categories []string               `yaml:"categories,omitempty"`
+	Tags       []string               `yaml:"tags,omitempty"`
+	Permalink  string                 `yaml:"permalink,omitempty"`
+	Author     string                 `yaml:"author,omitempty"`
+	Excerpt    string                 `yaml:"excerpt,omitempty"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+// splitFrontMatter separates a leading "---" YAML fence from the rest of
+// content. hadFrontMatter is false when content doesn't start with a fence,
+// in which case body is content unchanged.
+func splitFrontMatter(content []byte) (raw []byte, body []byte, hadFrontMatter bool) {
+	if !bytes.HasPrefix(content, []byte("---\n")) && !bytes.HasPrefix(content, []byte("---\r\n")) {
+		return nil, content, false
+	}
+
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(string(lines[i])) != "---" {
+			continue
+		}
+
+		var rawBuf, bodyBuf bytes.Buffer
+		for _, line := range lines[1:i] {
+			rawBuf.Write(line)
+		}
+		for _, line := range lines[i+1:] {
+			bodyBuf.Write(line)
+		}
+		return rawBuf.Bytes(), bodyBuf.Bytes(), true
+	}
+
+	return nil, content, false
+}
+
+// parseFrontMatter parses content's front matter (if any) into a
+// FrontMatter, returning the remaining body and whether a fence was
+// present. err is non-nil only when a fence was found but its YAML is
+// malformed.
+func parseFrontMatter(content []byte) (fm FrontMatter, body []byte, hadFrontMatter bool, err error) {
+	raw, body, hadFrontMatter := splitFrontMatter(content)
+	if !hadFrontMatter {
+		return FrontMatter{}, body, false, nil
+	}
+
+	if err := yaml.Unmarshal(raw, &fm); err != nil {
+		return FrontMatter{}, nil, true, err
+	}
+	return fm, body, true, nil
+}
+
+// Marshal renders fm as a "---" delimited YAML block.
+func (fm FrontMatter) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(data)
+	buf.WriteString("---\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name               string
+		content            string
+		wantFM             FrontMatter
+		wantBody           string
+		wantHadFrontMatter bool
+		wantErr            bool
+	}{
+		{
+			name:               "no front matter",
+			content:            "just a plain markdown post\n",
+			wantFM:             FrontMatter{},
+			wantBody:           "just a plain markdown post\n",
+			wantHadFrontMatter: false,
+		},
+		{
+			name: "simple fields",
+			content: "---\n" +
+				"title: Hello World\n" +
+				"date: 2024-01-02\n" +
+				"---\n" +
+				"body text\n",
+			wantFM:             FrontMatter{Title: "Hello World", Date: "2024-01-02"},
+			wantBody:           "body text\n",
+			wantHadFrontMatter: true,
+		},
+		{
+			name: "multi-line categories and tags",
+			content: "---\n" +
+				"title: Multi\n" +
+				"categories:\n" +
+				"  - blog\n" +
+				"  - go\n" +
+				"tags:\n" +
+				"  - api\n" +
+				"  - jekyll\n" +
+				"---\n" +
+				"body\n",
+			wantFM: FrontMatter{
+				Title:      "Multi",
+				Categories: []string{"blog", "go"},
+				Tags:       []string{"api", "jekyll"},
+			},
+			wantBody:           "body\n",
+			wantHadFrontMatter: true,
+		},
+		{
+			name: "quoted colon in value",
+			content: "---\n" +
+				"title: \"Go: A Tale of Two Languages\"\n" +
+				"excerpt: \"note: this is quoted\"\n" +
+				"---\n" +
+				"body\n",
+			wantFM: FrontMatter{
+				Title:   "Go: A Tale of Two Languages",
+				Excerpt: "note: this is quoted",
+			},
+			wantBody:           "body\n",
+			wantHadFrontMatter: true,
+		},
+		{
+			name: "nested extra map preserved",
+			content: "---\n" +
+				"title: Nested\n" +
+				"seo:\n" +
+				"  description: some text\n" +
+				"  noindex: true\n" +
+				"---\n" +
+				"body\n",
+			wantFM: FrontMatter{
+				Title: "Nested",
+				Extra: map[string]interface{}{
+					"seo": map[string]interface{}{
+						"description": "some text",
+						"noindex":     true,
+					},
+				},
+			},
+			wantBody:           "body\n",
+			wantHadFrontMatter: true,
+		},
+		{
+			name: "malformed yaml",
+			content: "---\n" +
+				"title: [unterminated\n" +
+				"---\n" +
+				"body\n",
+			wantHadFrontMatter: true,
+			wantErr:            true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, hadFrontMatter, err := parseFrontMatter([]byte(tt.content))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseFrontMatter() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFrontMatter() unexpected error: %v", err)
+			}
+			if hadFrontMatter != tt.wantHadFrontMatter {
+				t.Errorf("hadFrontMatter = %v, want %v", hadFrontMatter, tt.wantHadFrontMatter)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if !reflect.DeepEqual(fm, tt.wantFM) {
+				t.Errorf("fm = %+v, want %+v", fm, tt.wantFM)
+			}
+		})
+	}
+}
+
+func TestFrontMatterMarshalRoundTrip(t *testing.T) {
+	fm := FrontMatter{
+		Title:      "Round Trip: A Test",
+		Date:       "2024-01-02",
+		Categories: []string{"blog", "go"},
+		Tags:       []string{"api"},
+		Extra: map[string]interface{}{
+			"seo": map[string]interface{}{
+				"description": "some text",
+			},
+		},
+	}
+
+	data, err := fm.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+
+	got, body, hadFrontMatter, err := parseFrontMatter(append(data, []byte("body\n")...))
+	if err != nil {
+		t.Fatalf("parseFrontMatter() error: %v", err)
+	}
+	if !hadFrontMatter {
+		t.Fatalf("hadFrontMatter = false, want true")
+	}
+	if string(body) != "body\n" {
+		t.Errorf("body = %q, want %q", body, "body\n")
+	}
+	if !reflect.DeepEqual(got, fm) {
+		t.Errorf("round-tripped fm = %+v, want %+v", got, fm)
+	}
+}
+
+func TestSplitFrontMatterNoTrailingFence(t *testing.T) {
+	content := []byte("---\ntitle: Unterminated\nbody without closing fence\n")
+	raw, body, hadFrontMatter := splitFrontMatter(content)
+	if hadFrontMatter {
+		t.Fatalf("hadFrontMatter = true, want false when no closing fence exists")
+	}
+	if raw != nil {
+		t.Errorf("raw = %q, want nil", raw)
+	}
+	if string(body) != string(content) {
+		t.Errorf("body = %q, want original content unchanged", body)
+	}
+}
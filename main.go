@@ -1,8 +1,10 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -12,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,8 +25,12 @@ import (
 
 var (
 	postsDir     string
+	assetsDir    string
+	backendKind  string
 	maxFileSize  int64 = 10 << 20 // 10mb | Should never really be that much but just in case.
 	apiKeyHeader       = "X-API-Key"
+
+	backend StorageBackend
 )
 
 type Response struct {
@@ -39,6 +46,13 @@ type FileInfo struct {
 	ContentType string    `json:"content_type"`
 }
 
+// UploadResult extends FileInfo with the one-time delete key so the
+// uploader can revoke their own post later without the master API key.
+type UploadResult struct {
+	FileInfo
+	DeleteKey string `json:"delete_key"`
+}
+
 type UploadOptions struct {
 	Layout     string   `json:"layout"`
 	Title      string   `json:"title"`
@@ -46,11 +60,16 @@ type UploadOptions struct {
 	Categories []string `json:"categories"`
 }
 
-func init() {
+// loadConfig parses command-line flags and environment variables into the
+// package-level config vars. It's called explicitly from main() rather than
+// init() so `go test` doesn't choke on its own flags.
+func loadConfig() {
 	// Define command-line flags
 	postsDirFlag := flag.String("posts-dir", "", "Path to the Jekyll _posts directory")
+	assetsDirFlag := flag.String("assets-dir", "", "Path to the Jekyll assets directory")
 	portFlag := flag.String("port", "", "Port to run the server on")
 	apiKeyFlag := flag.String("api-key", "", "API key for authentication")
+	backendFlag := flag.String("backend", "", "Storage backend to use (localfs or s3)")
 
 	// Parse flags
 	flag.Parse()
@@ -66,6 +85,11 @@ func init() {
 		postsDir = getEnv("POSTS_DIR", filepath.Join(os.Getenv("HOME"), "blog", "_posts"))
 	}
 
+	assetsDir = *assetsDirFlag
+	if assetsDir == "" {
+		assetsDir = getEnv("ASSETS_DIR", filepath.Join(os.Getenv("HOME"), "blog", "assets"))
+	}
+
 	// Set API key if provided via flag
 	if *apiKeyFlag != "" {
 		os.Setenv("API_KEY", *apiKeyFlag)
@@ -75,6 +99,12 @@ func init() {
 	if *portFlag != "" {
 		os.Setenv("PORT", *portFlag)
 	}
+
+	// Set the storage backend, with the same priority as above
+	backendKind = *backendFlag
+	if backendKind == "" {
+		backendKind = getEnv("STORAGE_BACKEND", "localfs")
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -85,16 +115,17 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// processMarkdownFile ensures content has front matter containing at least
+// layout/title/date/categories, filling in only the fields the document
+// doesn't already set from options. Unknown front-matter keys and the
+// document's own values always win over options.
 func processMarkdownFile(content []byte, options UploadOptions, originalFilename string) ([]byte, error) {
-	// Get current date
-	currentDate := time.Now().Format("2006-01-02")
-
 	// Default values
 	if options.Layout == "" {
 		options.Layout = "page"
 	}
 	if options.Date == "" {
-		options.Date = currentDate
+		options.Date = time.Now().Format("2006-01-02")
 	}
 	if len(options.Categories) == 0 {
 		options.Categories = []string{"blog"}
@@ -107,80 +138,36 @@ func processMarkdownFile(content []byte, options UploadOptions, originalFilename
 		options.Title = strings.Title(strings.ReplaceAll(baseName, "-", " "))
 	}
 
-	// Check if file already has front matter
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	hasFrontMatter := false
-	if scanner.Scan() && strings.TrimSpace(scanner.Text()) == "---" {
-		hasFrontMatter = true
+	fm, body, hadFrontMatter, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing front matter: %w", err)
 	}
 
-	var newContent bytes.Buffer
-	if !hasFrontMatter {
-		// Add front matter
-		newContent.WriteString("---\n")
-		newContent.WriteString(fmt.Sprintf("layout: %s\n", options.Layout))
-		newContent.WriteString(fmt.Sprintf("title: %s\n", options.Title))
-		newContent.WriteString(fmt.Sprintf("date: %s\n", options.Date))
-		newContent.WriteString(fmt.Sprintf("categories: [%s]\n", strings.Join(options.Categories, ", ")))
-		newContent.WriteString("---\n\n")
-		newContent.Write(content)
-		return newContent.Bytes(), nil
-	}
-
-	// File has front matter, check and update if needed
-	var frontMatter bytes.Buffer
-	var contentAfterFrontMatter bytes.Buffer
-	inFrontMatter := true
-	hasLayout := false
-	hasTitle := false
-	hasDate := false
-	hasCategories := false
-
-	scanner = bufio.NewScanner(bytes.NewReader(content))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if inFrontMatter {
-			if line == "---" {
-				inFrontMatter = false
-				frontMatter.WriteString(line + "\n")
-				continue
-			}
-			if strings.HasPrefix(line, "layout:") {
-				hasLayout = true
-			}
-			if strings.HasPrefix(line, "title:") {
-				hasTitle = true
-			}
-			if strings.HasPrefix(line, "date:") {
-				hasDate = true
-			}
-			if strings.HasPrefix(line, "categories:") {
-				hasCategories = true
-			}
-			frontMatter.WriteString(line + "\n")
-		} else {
-			contentAfterFrontMatter.WriteString(line + "\n")
-		}
+	// Only fill in fields the document doesn't already set
+	if fm.Layout == "" {
+		fm.Layout = options.Layout
 	}
-
-	// Add missing front matter fields
-	if !hasLayout {
-		frontMatter.WriteString(fmt.Sprintf("layout: %s\n", options.Layout))
+	if fm.Title == "" {
+		fm.Title = options.Title
 	}
-	if !hasTitle {
-		frontMatter.WriteString(fmt.Sprintf("title: %s\n", options.Title))
+	if fm.Date == "" {
+		fm.Date = options.Date
 	}
-	if !hasDate {
-		frontMatter.WriteString(fmt.Sprintf("date: %s\n", options.Date))
+	if len(fm.Categories) == 0 {
+		fm.Categories = options.Categories
 	}
-	if !hasCategories {
-		frontMatter.WriteString(fmt.Sprintf("categories: [%s]\n", strings.Join(options.Categories, ", ")))
+
+	fmBytes, err := fm.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("marshaling front matter: %w", err)
 	}
 
-	// Combine everything
-	newContent.WriteString("---\n")
-	newContent.Write(frontMatter.Bytes())
-	newContent.Write(contentAfterFrontMatter.Bytes())
+	var newContent bytes.Buffer
+	newContent.Write(fmBytes)
+	if !hadFrontMatter {
+		newContent.WriteByte('\n')
+	}
+	newContent.Write(body)
 	return newContent.Bytes(), nil
 }
 
@@ -260,25 +247,72 @@ func handleFileUpload(w http.ResponseWriter, r *http.Request) {
 	// Format the filename
 	newFilename := formatFilename(handler.Filename, options.Title, options.Date)
 
-	// Create destination file
-	dst, err := os.Create(filepath.Join(postsDir, newFilename))
+	// Generate a delete key so the uploader can revoke this post later
+	// without the master API key
+	deleteKey, err := generateDeleteKey()
+	if err != nil {
+		respondWithError(w, "Error generating delete key", http.StatusInternalServerError)
+		return
+	}
+	deleteKeyHash, err := hashDeleteKey(deleteKey)
 	if err != nil {
-		respondWithError(w, "Error creating file", http.StatusInternalServerError)
+		respondWithError(w, "Error generating delete key", http.StatusInternalServerError)
 		return
 	}
-	defer dst.Close()
 
-	// Write processed content
-	if _, err := dst.Write(processedContent); err != nil {
+	// Parse an optional expiry, e.g. "expires_in_minutes=60"
+	var expiresAt *time.Time
+	if raw := r.FormValue("expires_in_minutes"); raw != "" {
+		minutes, err := strconv.Atoi(raw)
+		if err != nil || minutes <= 0 {
+			respondWithError(w, "Invalid expires_in_minutes", http.StatusBadRequest)
+			return
+		}
+		t := time.Now().Add(time.Duration(minutes) * time.Minute)
+		expiresAt = &t
+	}
+
+	sum := sha256.Sum256(content)
+	uploadTime := time.Now()
+	meta := PostMetadata{
+		Filename:         newFilename,
+		OriginalFilename: handler.Filename,
+		SHA256:           hex.EncodeToString(sum[:]),
+		Size:             int64(len(processedContent)),
+		UploadTime:       uploadTime,
+		DeleteKeyHash:    deleteKeyHash,
+		ExpiresAt:        expiresAt,
+	}
+
+	// Write processed content to the configured storage backend
+	if err := backend.Put(r.Context(), newFilename, bytes.NewReader(processedContent)); err != nil {
 		respondWithError(w, "Error saving file", http.StatusInternalServerError)
 		return
 	}
 
-	respondWithSuccess(w, "File uploaded successfully", FileInfo{
-		Filename:    newFilename,
-		Size:        int64(len(processedContent)),
-		UploadTime:  time.Now(),
-		ContentType: handler.Header.Get("Content-Type"),
+	// The post is now live; if its metadata sidecar can't be saved, remove
+	// the post rather than leave behind one with no delete key and no way
+	// for the uploader to revoke it short of the master API key.
+	if err := saveMetadata(r.Context(), meta); err != nil {
+		if delErr := backend.Delete(r.Context(), newFilename); delErr != nil {
+			log.Printf("Warning: error rolling back %s after metadata save failure: %v", newFilename, delErr)
+		}
+		respondWithError(w, "Error saving metadata", http.StatusInternalServerError)
+		return
+	}
+
+	if err := searchIndex.update(r.Context(), newFilename); err != nil {
+		log.Printf("Warning: error updating search index for %s: %v", newFilename, err)
+	}
+
+	respondWithSuccess(w, "File uploaded successfully", UploadResult{
+		FileInfo: FileInfo{
+			Filename:    newFilename,
+			Size:        int64(len(processedContent)),
+			UploadTime:  uploadTime,
+			ContentType: handler.Header.Get("Content-Type"),
+		},
+		DeleteKey: deleteKey,
 	})
 }
 
@@ -288,28 +322,37 @@ func listFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, err := os.ReadDir(postsDir)
+	fileInfos, err := backend.List(r.Context())
 	if err != nil {
 		respondWithError(w, "Error reading directory", http.StatusInternalServerError)
 		return
 	}
 
-	var fileInfos []FileInfo
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(strings.ToLower(file.Name()), ".md") {
-			info, err := file.Info()
-			if err != nil {
-				continue
-			}
-			fileInfos = append(fileInfos, FileInfo{
-				Filename:    file.Name(),
-				Size:        info.Size(),
-				UploadTime:  info.ModTime(),
-				ContentType: "text/markdown",
-			})
+	payload, err := json.Marshal(fileInfos)
+	if err != nil {
+		respondWithError(w, "Error building response", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(payload)
+	etag := quoteETag(hex.EncodeToString(sum[:]))
+
+	var lastModified time.Time
+	for _, fi := range fileInfos {
+		if fi.UploadTime.After(lastModified) {
+			lastModified = fi.UploadTime
 		}
 	}
 
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	respondWithSuccess(w, "Files retrieved successfully", fileInfos)
 }
 
@@ -322,29 +365,56 @@ func getFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
 
-	filePath := filepath.Join(postsDir, filename)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	exists, err := backend.Exists(r.Context(), filename)
+	if err != nil || !exists {
 		respondWithError(w, "File not found", http.StatusNotFound)
 		return
 	}
 
-	http.ServeFile(w, r, filePath)
-}
+	sum, modTime, err := contentSHA256(r.Context(), filename)
+	if err != nil {
+		respondWithError(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", quoteETag(sum))
 
-func deleteFile(w http.ResponseWriter, r *http.Request) {
-	if !validateAPIKey(r) {
-		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+	rc, err := backend.Get(r.Context(), filename)
+	if err != nil {
+		respondWithError(w, "Error reading file", http.StatusInternalServerError)
 		return
 	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		respondWithError(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+
+	// http.ServeContent honors the ETag we just set (If-None-Match),
+	// If-Modified-Since, and Range requests.
+	http.ServeContent(w, r, filename, modTime, bytes.NewReader(content))
+}
 
+func deleteFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
 
-	filePath := filepath.Join(postsDir, filename)
-	if err := os.Remove(filePath); err != nil {
+	// Writers can revoke their own post with its delete key; the master
+	// API key can delete anything
+	if !validateAPIKey(r) && !validateDeleteKey(r, filename) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := backend.Delete(r.Context(), filename); err != nil {
 		respondWithError(w, "Error deleting file", http.StatusInternalServerError)
 		return
 	}
+	if err := deleteMetadata(r.Context(), filename); err != nil {
+		log.Printf("Warning: error deleting metadata for %s: %v", filename, err)
+	}
+	searchIndex.remove(filename)
 
 	respondWithSuccess(w, "File deleted successfully", nil)
 }
@@ -374,8 +444,38 @@ func respondWithSuccess(w http.ResponseWriter, message string, data interface{})
 }
 
 func main() {
-	// Create necessary directory
-	os.MkdirAll(postsDir, 0755)
+	loadConfig()
+
+	// Initialize the configured storage backend
+	b, err := newStorageBackend(backendKind)
+	if err != nil {
+		log.Fatalf("Error initializing storage backend %q: %v", backendKind, err)
+	}
+	backend = b
+
+	ab, err := newAssetsBackend(backendKind)
+	if err != nil {
+		log.Fatalf("Error initializing assets backend %q: %v", backendKind, err)
+	}
+	assetsBackend = ab
+
+	// Periodically remove posts whose metadata says they've expired
+	sweepMinutes, err := strconv.Atoi(getEnv("EXPIRY_SWEEP_MINUTES", "5"))
+	if err != nil || sweepMinutes <= 0 {
+		sweepMinutes = 5
+	}
+	go startExpirySweeper(time.Duration(sweepMinutes) * time.Minute)
+
+	// Build the full-text search index and keep it warm in the background
+	searchIndex = newSearchIndex()
+	if err := searchIndex.rebuild(context.Background()); err != nil {
+		log.Printf("Warning: error building search index: %v", err)
+	}
+	rebuildMinutes, err := strconv.Atoi(getEnv("SEARCH_INDEX_REBUILD_MINUTES", "10"))
+	if err != nil || rebuildMinutes <= 0 {
+		rebuildMinutes = 10
+	}
+	go startSearchIndexRebuilder(time.Duration(rebuildMinutes) * time.Minute)
 
 	// Initialize router
 	r := mux.NewRouter()
@@ -385,11 +485,16 @@ func main() {
 	r.HandleFunc("/files", listFiles).Methods("GET")
 	r.HandleFunc("/files/{filename}", getFile).Methods("GET")
 	r.HandleFunc("/files/{filename}", deleteFile).Methods("DELETE")
+	r.HandleFunc("/files/{filename}", patchFile).Methods("PATCH")
+	r.HandleFunc("/search", searchFiles).Methods("GET")
+	r.HandleFunc("/assets", uploadAsset).Methods("POST")
+	r.HandleFunc("/assets/{key:.*}", getAsset).Methods("GET")
+	r.HandleFunc("/assets/{key:.*}", deleteAsset).Methods("DELETE")
 
 	// CORS configuration
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "X-API-Key"},
 		AllowCredentials: true,
 	})
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const deleteKeyHeader = "X-Delete-Key"
+
+// PostMetadata is the sidecar persisted alongside each uploaded post. It
+// lets writers revoke their own posts with a delete key instead of the
+// master API key, and lets posts optionally expire on their own.
+type PostMetadata struct {
+	Filename         string     `json:"filename"`
+	OriginalFilename string     `json:"original_filename"`
+	SHA256           string     `json:"sha256"`
+	Size             int64      `json:"size"`
+	UploadTime       time.Time  `json:"upload_time"`
+	DeleteKeyHash    string     `json:"delete_key_hash"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
+
+func metadataKey(filename string) string {
+	return "_meta/" + filename + ".json"
+}
+
+func saveMetadata(ctx context.Context, meta PostMetadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return backend.Put(ctx, metadataKey(meta.Filename), bytes.NewReader(data))
+}
+
+func loadMetadata(ctx context.Context, filename string) (*PostMetadata, error) {
+	r, err := backend.Get(ctx, metadataKey(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var meta PostMetadata
+	if err := json.NewDecoder(r).Decode(&meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func deleteMetadata(ctx context.Context, filename string) error {
+	return backend.Delete(ctx, metadataKey(filename))
+}
+
+// generateDeleteKey returns a random, URL-safe key handed back to the
+// uploader once. Only its bcrypt hash is ever persisted.
+func generateDeleteKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashDeleteKey(key string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func verifyDeleteKey(hash, key string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(key)) == nil
+}
+
+// validateDeleteKey checks the X-Delete-Key header on r against the stored
+// hash for filename, so a writer can revoke their own post without the
+// master API key.
+func validateDeleteKey(r *http.Request, filename string) bool {
+	key := r.Header.Get(deleteKeyHeader)
+	if key == "" {
+		return false
+	}
+
+	meta, err := loadMetadata(r.Context(), filename)
+	if err != nil {
+		return false
+	}
+	return verifyDeleteKey(meta.DeleteKeyHash, key)
+}
+
+// startExpirySweeper periodically removes posts whose metadata carries an
+// expiry timestamp in the past. It never returns and is meant to be
+// launched with `go`.
+func startExpirySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		sweepExpiredPosts(context.Background())
+	}
+}
+
+func sweepExpiredPosts(ctx context.Context) {
+	posts, err := backend.List(ctx)
+	if err != nil {
+		log.Printf("expiry sweep: error listing posts: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, post := range posts {
+		meta, err := loadMetadata(ctx, post.Filename)
+		if err != nil || meta.ExpiresAt == nil || meta.ExpiresAt.After(now) {
+			continue
+		}
+
+		if err := backend.Delete(ctx, post.Filename); err != nil {
+			log.Printf("expiry sweep: error deleting %s: %v", post.Filename, err)
+			continue
+		}
+		if err := deleteMetadata(ctx, post.Filename); err != nil {
+			log.Printf("expiry sweep: error deleting metadata for %s: %v", post.Filename, err)
+		}
+		searchIndex.remove(post.Filename)
+		log.Printf("expiry sweep: removed expired post %s", post.Filename)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestDeleteKeyHashAndVerify(t *testing.T) {
+	key, err := generateDeleteKey()
+	if err != nil {
+		t.Fatalf("generateDeleteKey() error: %v", err)
+	}
+	if key == "" {
+		t.Fatalf("generateDeleteKey() returned empty key")
+	}
+
+	hash, err := hashDeleteKey(key)
+	if err != nil {
+		t.Fatalf("hashDeleteKey() error: %v", err)
+	}
+
+	if !verifyDeleteKey(hash, key) {
+		t.Errorf("verifyDeleteKey() = false for the correct key, want true")
+	}
+	if verifyDeleteKey(hash, "wrong-key") {
+		t.Errorf("verifyDeleteKey() = true for an incorrect key, want false")
+	}
+}
+
+func TestGenerateDeleteKeyIsUnique(t *testing.T) {
+	a, err := generateDeleteKey()
+	if err != nil {
+		t.Fatalf("generateDeleteKey() error: %v", err)
+	}
+	b, err := generateDeleteKey()
+	if err != nil {
+		t.Fatalf("generateDeleteKey() error: %v", err)
+	}
+	if a == b {
+		t.Errorf("generateDeleteKey() returned the same key twice: %q", a)
+	}
+}
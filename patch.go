@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// patchLocks holds one mutex per filename so the If-Match check and the
+// eventual backend.Put for a PATCH happen atomically with respect to other
+// PATCHes on the same file, closing the lost-update window between them.
+var patchLocks sync.Map // filename -> *sync.Mutex
+
+func lockForPatch(filename string) *sync.Mutex {
+	m, _ := patchLocks.LoadOrStore(filename, &sync.Mutex{})
+	return m.(*sync.Mutex)
+}
+
+// PostPatch is the JSON body accepted by PATCH /files/{filename} for
+// merging front-matter fields into an existing post. Pointer fields
+// distinguish "not provided" from "set to empty string"; Categories and
+// Tags are replaced wholesale when present.
+type PostPatch struct {
+	Title      *string  `json:"title,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Author     *string  `json:"author,omitempty"`
+	Excerpt    *string  `json:"excerpt,omitempty"`
+	Permalink  *string  `json:"permalink,omitempty"`
+	BodyAppend string   `json:"body_append,omitempty"`
+}
+
+// patchFile handles PATCH /files/{filename}. The body is either a full
+// markdown replacement (any non-JSON Content-Type) or a PostPatch to merge
+// into the existing front matter and append to the body. An If-Match
+// header carrying the post's previous ETag guards against lost updates
+// from concurrent editors.
+func patchFile(w http.ResponseWriter, r *http.Request) {
+	if !validateAPIKey(r) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filename := mux.Vars(r)["filename"]
+
+	// Hold this file's lock across the If-Match check and the write below
+	// so two concurrent PATCHes carrying the same If-Match value can't both
+	// pass the check and clobber each other.
+	lock := lockForPatch(filename)
+	lock.Lock()
+	defer lock.Unlock()
+
+	exists, err := backend.Exists(r.Context(), filename)
+	if err != nil || !exists {
+		respondWithError(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	currentSum, _, err := contentSHA256(r.Context(), filename)
+	if err != nil {
+		respondWithError(w, "Error reading file", http.StatusInternalServerError)
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != quoteETag(currentSum) {
+		respondWithError(w, "File has changed since If-Match ETag", http.StatusPreconditionFailed)
+		return
+	}
+
+	var newContent []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var patch PostPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			respondWithError(w, "Invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		newContent, err = applyPostPatch(r.Context(), filename, patch)
+		if err != nil {
+			respondWithError(w, "Error applying patch", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		r.Body = http.MaxBytesReader(w, r.Body, maxFileSize)
+		newContent, err = io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	if err := backend.Put(r.Context(), filename, bytes.NewReader(newContent)); err != nil {
+		respondWithError(w, "Error saving file", http.StatusInternalServerError)
+		return
+	}
+
+	size, modTime, err := backend.Stat(r.Context(), filename)
+	if err != nil {
+		respondWithError(w, "Error reading updated file", http.StatusInternalServerError)
+		return
+	}
+
+	// Keep the metadata sidecar and search index consistent with the edit
+	if meta, err := loadMetadata(r.Context(), filename); err == nil {
+		sum := sha256.Sum256(newContent)
+		meta.Size = size
+		meta.UploadTime = modTime
+		meta.SHA256 = hex.EncodeToString(sum[:])
+		if err := saveMetadata(r.Context(), *meta); err != nil {
+			log.Printf("Warning: error updating metadata for %s: %v", filename, err)
+		}
+	}
+	if err := searchIndex.update(r.Context(), filename); err != nil {
+		log.Printf("Warning: error updating search index for %s: %v", filename, err)
+	}
+
+	respondWithSuccess(w, "File updated successfully", FileInfo{
+		Filename:    filename,
+		Size:        size,
+		UploadTime:  modTime,
+		ContentType: "text/markdown",
+	})
+}
+
+// applyPostPatch loads filename through the front-matter parser, merges
+// patch into it, and appends patch.BodyAppend to the body.
+func applyPostPatch(ctx context.Context, filename string, patch PostPatch) ([]byte, error) {
+	r, err := backend.Get(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, body, hadFrontMatter, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Title != nil {
+		fm.Title = *patch.Title
+	}
+	if patch.Categories != nil {
+		fm.Categories = patch.Categories
+	}
+	if patch.Tags != nil {
+		fm.Tags = patch.Tags
+	}
+	if patch.Author != nil {
+		fm.Author = *patch.Author
+	}
+	if patch.Excerpt != nil {
+		fm.Excerpt = *patch.Excerpt
+	}
+	if patch.Permalink != nil {
+		fm.Permalink = *patch.Permalink
+	}
+
+	var out bytes.Buffer
+	if hadFrontMatter || patchTouchesFrontMatter(patch) {
+		fmBytes, err := fm.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		out.Write(fmBytes)
+		if !hadFrontMatter {
+			out.WriteByte('\n')
+		}
+	}
+	out.Write(body)
+	out.WriteString(patch.BodyAppend)
+	return out.Bytes(), nil
+}
+
+// patchTouchesFrontMatter reports whether patch sets any front-matter
+// field, so a body_append-only patch on a fence-less document doesn't
+// inject a front-matter block the document never had.
+func patchTouchesFrontMatter(patch PostPatch) bool {
+	return patch.Title != nil ||
+		patch.Categories != nil ||
+		patch.Tags != nil ||
+		patch.Author != nil ||
+		patch.Excerpt != nil ||
+		patch.Permalink != nil
+}
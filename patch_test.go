@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApplyPostPatchBodyAppendOnlySkipsFence(t *testing.T) {
+	dir := t.TempDir()
+	b, err := newLocalFSBackend(dir)
+	if err != nil {
+		t.Fatalf("newLocalFSBackend() error: %v", err)
+	}
+
+	origBackend := backend
+	backend = b
+	defer func() { backend = origBackend }()
+
+	const filename = "plain.md"
+	original := "no front matter here\n"
+	if err := backend.Put(context.Background(), filename, strings.NewReader(original)); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	got, err := applyPostPatch(context.Background(), filename, PostPatch{BodyAppend: "more text\n"})
+	if err != nil {
+		t.Fatalf("applyPostPatch() error: %v", err)
+	}
+
+	want := original + "more text\n"
+	if string(got) != want {
+		t.Errorf("applyPostPatch() = %q, want %q (no front-matter fence should be injected)", got, want)
+	}
+}
+
+func TestApplyPostPatchSetsFrontMatterOnFenceLessDoc(t *testing.T) {
+	dir := t.TempDir()
+	b, err := newLocalFSBackend(dir)
+	if err != nil {
+		t.Fatalf("newLocalFSBackend() error: %v", err)
+	}
+
+	origBackend := backend
+	backend = b
+	defer func() { backend = origBackend }()
+
+	const filename = "plain.md"
+	if err := backend.Put(context.Background(), filename, strings.NewReader("body only\n")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+
+	title := "New Title"
+	got, err := applyPostPatch(context.Background(), filename, PostPatch{Title: &title})
+	if err != nil {
+		t.Fatalf("applyPostPatch() error: %v", err)
+	}
+
+	_, body, hadFrontMatter, err := parseFrontMatter(got)
+	if err != nil {
+		t.Fatalf("parseFrontMatter() error: %v", err)
+	}
+	if !hadFrontMatter {
+		t.Errorf("applyPostPatch() produced no front matter, want a fence since the patch set title")
+	}
+	if string(body) != "\nbody only\n" {
+		t.Errorf("body = %q, want %q", body, "\nbody only\n")
+	}
+}
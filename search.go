@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var searchIndex *SearchIndex
+
+var (
+	wordRe      = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	liquidTagRe = regexp.MustCompile(`\{\{.*?\}\}|\{%.*?%\}`)
+)
+
+// indexedDoc is the in-memory representation of one post used by
+// SearchIndex. tokens holds term frequencies for scoring.
+type indexedDoc struct {
+	Filename   string
+	Title      string
+	Date       string
+	Categories []string
+	Tags       []string
+	Body       string
+	Size       int64
+	UploadTime time.Time
+	tokens     map[string]int
+}
+
+// SearchResult is a ranked, FileInfo-like entry returned by GET /search.
+type SearchResult struct {
+	FileInfo
+	Title      string   `json:"title,omitempty"`
+	Score      float64  `json:"score"`
+	Snippet    string   `json:"snippet"`
+	Categories []string `json:"categories,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// SearchIndex is an in-process inverted index over post bodies and front
+// matter, similar in spirit to gohttpserver's makeIndex. It's rebuilt
+// wholesale on a timer and updated incrementally on upload/delete.
+type SearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[string]*indexedDoc
+	postings map[string]map[string]int // token -> filename -> term frequency
+}
+
+func newSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		docs:     make(map[string]*indexedDoc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// rebuild walks every post in the storage backend and replaces the index
+// wholesale. It's safe to call concurrently with searches.
+func (idx *SearchIndex) rebuild(ctx context.Context) error {
+	posts, err := backend.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	docs := make(map[string]*indexedDoc, len(posts))
+	postings := make(map[string]map[string]int)
+	for _, post := range posts {
+		doc, err := buildIndexedDoc(ctx, post.Filename)
+		if err != nil {
+			log.Printf("search index: skipping %s: %v", post.Filename, err)
+			continue
+		}
+		docs[post.Filename] = doc
+		for token, freq := range doc.tokens {
+			if postings[token] == nil {
+				postings[token] = make(map[string]int)
+			}
+			postings[token][post.Filename] = freq
+		}
+	}
+
+	idx.mu.Lock()
+	idx.docs = docs
+	idx.postings = postings
+	idx.mu.Unlock()
+	return nil
+}
+
+// update re-indexes a single post, e.g. right after it's uploaded.
+func (idx *SearchIndex) update(ctx context.Context, filename string) error {
+	doc, err := buildIndexedDoc(ctx, filename)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(filename)
+	idx.docs[filename] = doc
+	for token, freq := range doc.tokens {
+		if idx.postings[token] == nil {
+			idx.postings[token] = make(map[string]int)
+		}
+		idx.postings[token][filename] = freq
+	}
+	return nil
+}
+
+// remove drops a post from the index, e.g. right after it's deleted.
+func (idx *SearchIndex) remove(filename string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(filename)
+}
+
+func (idx *SearchIndex) removeLocked(filename string) {
+	delete(idx.docs, filename)
+	for token, filenames := range idx.postings {
+		delete(filenames, filename)
+		if len(filenames) == 0 {
+			delete(idx.postings, token)
+		}
+	}
+}
+
+// search ranks posts by summed term frequency over the query tokens,
+// optionally filtered by front-matter category and a minimum post date.
+func (idx *SearchIndex) search(query, category string, from time.Time) []SearchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := make(map[string]float64)
+	for token := range tokenize(query) {
+		for filename, freq := range idx.postings[token] {
+			scores[filename] += float64(freq)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for filename, score := range scores {
+		doc := idx.docs[filename]
+		if doc == nil {
+			continue
+		}
+		if category != "" && !hasCategory(doc.Categories, category) {
+			continue
+		}
+		if !from.IsZero() {
+			docDate, err := time.Parse("2006-01-02", doc.Date)
+			if err != nil || docDate.Before(from) {
+				continue
+			}
+		}
+
+		results = append(results, SearchResult{
+			FileInfo: FileInfo{
+				Filename:    doc.Filename,
+				Size:        doc.Size,
+				UploadTime:  doc.UploadTime,
+				ContentType: "text/markdown",
+			},
+			Title:      doc.Title,
+			Score:      score,
+			Snippet:    buildSnippet(doc.Body, query),
+			Categories: doc.Categories,
+			Tags:       doc.Tags,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results
+}
+
+func hasCategory(categories []string, want string) bool {
+	for _, c := range categories {
+		if strings.EqualFold(c, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSnippet returns a short window of body around the first matched
+// query token, with the match wrapped in ** markers.
+func buildSnippet(body, query string) string {
+	terms := tokenize(query)
+
+	matchStart := -1
+	matchLen := 0
+	for _, loc := range wordRe.FindAllStringIndex(body, -1) {
+		if terms[strings.ToLower(body[loc[0]:loc[1]])] > 0 {
+			matchStart = loc[0]
+			matchLen = loc[1] - loc[0]
+			break
+		}
+	}
+	if matchStart == -1 {
+		if len(body) <= 160 {
+			return body
+		}
+		return strings.TrimSpace(body[:160]) + "..."
+	}
+
+	const radius = 60
+	start := matchStart - radius
+	if start < 0 {
+		start = 0
+	}
+	end := matchStart + matchLen + radius
+	if end > len(body) {
+		end = len(body)
+	}
+
+	snippet := body[start:matchStart] + "**" + body[matchStart:matchStart+matchLen] + "**" + body[matchStart+matchLen:end]
+	return strings.TrimSpace(snippet)
+}
+
+// tokenize lowercases s and splits it into Unicode word-boundary tokens,
+// counting occurrences of each.
+func tokenize(s string) map[string]int {
+	tokens := make(map[string]int)
+	for _, tok := range wordRe.FindAllString(strings.ToLower(s), -1) {
+		tokens[tok]++
+	}
+	return tokens
+}
+
+// stripLiquidTags removes Jekyll Liquid `{{ ... }}` and `{% ... %}` tags so
+// template syntax doesn't pollute the index.
+func stripLiquidTags(s string) string {
+	return liquidTagRe.ReplaceAllString(s, " ")
+}
+
+
+func buildIndexedDoc(ctx context.Context, filename string) (*indexedDoc, error) {
+	r, err := backend.Get(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, body, _, err := parseFrontMatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing front matter: %w", err)
+	}
+	cleanBody := stripLiquidTags(string(body))
+
+	var size int64
+	var uploadTime time.Time
+	if meta, err := loadMetadata(ctx, filename); err == nil {
+		size = meta.Size
+		uploadTime = meta.UploadTime
+	}
+
+	return &indexedDoc{
+		Filename:   filename,
+		Title:      fm.Title,
+		Date:       fm.Date,
+		Categories: fm.Categories,
+		Tags:       fm.Tags,
+		Body:       cleanBody,
+		Size:       size,
+		UploadTime: uploadTime,
+		tokens:     tokenize(fm.Title + " " + cleanBody),
+	}, nil
+}
+
+// startSearchIndexRebuilder periodically rebuilds searchIndex from scratch
+// so it stays consistent even if incremental updates are missed. It never
+// returns and is meant to be launched with `go`.
+func startSearchIndexRebuilder(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := searchIndex.rebuild(context.Background()); err != nil {
+			log.Printf("search index rebuild: %v", err)
+		}
+	}
+}
+
+func searchFiles(w http.ResponseWriter, r *http.Request) {
+	if !validateAPIKey(r) {
+		respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		respondWithError(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	var from time.Time
+	if rawFrom := r.URL.Query().Get("from"); rawFrom != "" {
+		parsed, err := time.Parse("2006-01-02", rawFrom)
+		if err != nil {
+			respondWithError(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	results := searchIndex.search(query, r.URL.Query().Get("category"), from)
+	respondWithSuccess(w, "Search results retrieved successfully", results)
+}
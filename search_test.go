@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSnippetHighlightsMatch(t *testing.T) {
+	body := "This is a post about matchme keyword here for testing purposes."
+	got := buildSnippet(body, "matchme")
+	want := "This is a post about **matchme** keyword here for testing purposes."
+	if got != want {
+		t.Errorf("buildSnippet() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSnippetUnicodeCaseFolding(t *testing.T) {
+	// İ (U+0130) lowercases to a 2-byte "i̇" in full Unicode case folding,
+	// but strings.ToLower only performs simple case folding; either way its
+	// byte length differs from the original rune, which used to desync the
+	// snippet's offsets from the source body. The match must still land on
+	// exactly "matchme".
+	body := "İstanbul title about matchme keyword here."
+	got := buildSnippet(body, "matchme")
+	want := "İstanbul title about **matchme** keyword here."
+	if got != want {
+		t.Errorf("buildSnippet() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSnippetNoMatchTruncates(t *testing.T) {
+	body := "no query terms appear anywhere in this short body."
+	got := buildSnippet(body, "absent")
+	if got != body {
+		t.Errorf("buildSnippet() = %q, want unchanged body %q", got, body)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Go Go gopher!")
+	want := map[string]int{"go": 2, "gopher": 1}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("tokenize()[%q] = %d, want %d", k, got[k], v)
+		}
+	}
+}
+
+func TestSearchResultIncludesMatchedTitle(t *testing.T) {
+	idx := newSearchIndex()
+	doc := &indexedDoc{
+		Filename:   "2024-01-01-post.md",
+		Title:      "Matchme Title",
+		Categories: []string{"Blog"},
+		Body:       "body text",
+		UploadTime: time.Now(),
+		tokens:     tokenize("Matchme Title body text"),
+	}
+	idx.docs[doc.Filename] = doc
+	for token, freq := range doc.tokens {
+		idx.postings[token] = map[string]int{doc.Filename: freq}
+	}
+
+	results := idx.search("matchme", "", time.Time{})
+	if len(results) != 1 {
+		t.Fatalf("search() returned %d results, want 1", len(results))
+	}
+	if results[0].Title != doc.Title {
+		t.Errorf("results[0].Title = %q, want %q", results[0].Title, doc.Title)
+	}
+}
+
+func TestHasCategory(t *testing.T) {
+	categories := []string{"Blog", "Go"}
+	if !hasCategory(categories, "blog") {
+		t.Errorf("hasCategory() = false, want true (case-insensitive match)")
+	}
+	if hasCategory(categories, "rust") {
+		t.Errorf("hasCategory() = true, want false")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StorageBackend abstracts where uploaded Jekyll posts are persisted so the
+// API can write to local disk or to an S3-compatible bucket interchangeably.
+type StorageBackend interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]FileInfo, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	ServeFile(w http.ResponseWriter, r *http.Request, key string) error
+	// Stat returns the size and modification time of key, for building
+	// ETag/Last-Modified/cache-key values without reading the whole object.
+	Stat(ctx context.Context, key string) (size int64, modTime time.Time, err error)
+}
+
+// newStorageBackend builds the StorageBackend selected via --backend /
+// STORAGE_BACKEND. "localfs" (the default) keeps the original on-disk
+// behaviour; "s3" writes posts to an S3-compatible bucket instead.
+func newStorageBackend(kind string) (StorageBackend, error) {
+	switch kind {
+	case "", "localfs":
+		return newLocalFSBackend(postsDir)
+	case "s3":
+		return newS3Backend()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", kind)
+	}
+}
+
+// newAssetsBackend builds the StorageBackend used for non-markdown assets
+// (images, PDFs, audio). It shares the backend kind with posts, but keeps
+// assets in their own root: assetsDir for localfs, a distinct S3 prefix
+// for s3.
+func newAssetsBackend(kind string) (StorageBackend, error) {
+	switch kind {
+	case "", "localfs":
+		return newLocalFSBackend(assetsDir)
+	case "s3":
+		return newS3BackendWithPrefix(getEnv("S3_ASSETS_PREFIX", "assets"))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", kind)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalFSBackend stores posts as plain files under a directory on local
+// disk. It is the original, default behaviour of the API.
+type LocalFSBackend struct {
+	dir string
+}
+
+func newLocalFSBackend(dir string) (*LocalFSBackend, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalFSBackend{dir: dir}, nil
+}
+
+// Put writes to a temp file in the same directory and renames it into
+// place, so concurrent readers never see a partially-written file and a
+// failed write never corrupts an existing one.
+func (b *LocalFSBackend) Put(ctx context.Context, key string, r io.Reader) error {
+	dst := filepath.Join(b.dir, key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, dst)
+}
+
+func (b *LocalFSBackend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(b.dir, key))
+}
+
+func (b *LocalFSBackend) List(ctx context.Context) ([]FileInfo, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileInfos []FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".md") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fileInfos = append(fileInfos, FileInfo{
+			Filename:    entry.Name(),
+			Size:        info.Size(),
+			UploadTime:  info.ModTime(),
+			ContentType: "text/markdown",
+		})
+	}
+	return fileInfos, nil
+}
+
+func (b *LocalFSBackend) Delete(ctx context.Context, key string) error {
+	return os.Remove(filepath.Join(b.dir, key))
+}
+
+func (b *LocalFSBackend) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(filepath.Join(b.dir, key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *LocalFSBackend) Stat(ctx context.Context, key string) (int64, time.Time, error) {
+	info, err := os.Stat(filepath.Join(b.dir, key))
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return info.Size(), info.ModTime(), nil
+}
+
+func (b *LocalFSBackend) ServeFile(w http.ResponseWriter, r *http.Request, key string) error {
+	path := filepath.Join(b.dir, key)
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	http.ServeFile(w, r, path)
+	return nil
+}
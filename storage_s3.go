@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Backend stores posts as objects in an S3 (or S3-compatible) bucket so
+// teams can host their Jekyll _posts on object storage and rebuild the site
+// from a bucket rather than a single VM's disk.
+type S3Backend struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// newS3Backend builds an S3Backend from S3_* environment variables:
+// S3_BUCKET (required), S3_REGION, S3_ENDPOINT (for S3-compatible services
+// like MinIO), S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY, and S3_PREFIX.
+func newS3Backend() (*S3Backend, error) {
+	return newS3BackendWithPrefix(getEnv("S3_PREFIX", ""))
+}
+
+// newS3BackendWithPrefix builds an S3Backend like newS3Backend, but stores
+// objects under prefix instead of S3_PREFIX. Used to keep assets in their
+// own prefix alongside posts in the same bucket.
+func newS3BackendWithPrefix(prefix string) (*S3Backend, error) {
+	bucket := getEnv("S3_BUCKET", "")
+	if bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET must be set when STORAGE_BACKEND=s3")
+	}
+
+	region := getEnv("S3_REGION", "us-east-1")
+	endpoint := getEnv("S3_ENDPOINT", "")
+	accessKey := getEnv("S3_ACCESS_KEY_ID", "")
+	secretKey := getEnv("S3_SECRET_ACCESS_KEY", "")
+
+	optFns := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if accessKey != "" && secretKey != "" {
+		optFns = append(optFns, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
+		))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Backend{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (b *S3Backend) objectKey(key string) string {
+	if b.prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(b.prefix, "/") + "/" + key
+}
+
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader) error {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+		Body:   r,
+	})
+	return err
+}
+
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (b *S3Backend) List(ctx context.Context) ([]FileInfo, error) {
+	var fileInfos []FileInfo
+	var continuationToken *string
+
+	for {
+		out, err := b.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(b.bucket),
+			Prefix:            aws.String(b.prefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range out.Contents {
+			name := strings.TrimPrefix(strings.TrimPrefix(aws.ToString(obj.Key), b.prefix), "/")
+			if name == "" || !strings.HasSuffix(strings.ToLower(name), ".md") {
+				continue
+			}
+			fileInfos = append(fileInfos, FileInfo{
+				Filename:    name,
+				Size:        aws.ToInt64(obj.Size),
+				UploadTime:  aws.ToTime(obj.LastModified),
+				ContentType: "text/markdown",
+			})
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		continuationToken = out.NextContinuationToken
+	}
+
+	return fileInfos, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	return err
+}
+
+func (b *S3Backend) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *S3Backend) Stat(ctx context.Context, key string) (int64, time.Time, error) {
+	out, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return aws.ToInt64(out.ContentLength), aws.ToTime(out.LastModified), nil
+}
+
+func (b *S3Backend) ServeFile(w http.ResponseWriter, r *http.Request, key string) error {
+	out, err := b.client.GetObject(r.Context(), &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		return err
+	}
+	defer out.Body.Close()
+
+	content, err := io.ReadAll(out.Body)
+	if err != nil {
+		return err
+	}
+
+	if etag := aws.ToString(out.ETag); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	// http.ServeContent honors the ETag we just set (If-None-Match),
+	// If-Modified-Since, and Range requests, the same conditional-GET
+	// support LocalFSBackend gets for free from http.ServeFile.
+	http.ServeContent(w, r, key, aws.ToTime(out.LastModified), bytes.NewReader(content))
+	return nil
+}